@@ -0,0 +1,298 @@
+// Package migrations applies the embedded SQL migrations that bootstrap the
+// service's schema, so a fresh deployment doesn't crash on its first request.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// lockKey is the pg_advisory_lock key used to serialize migration runs across
+// pods. A rollout or HPA scale-up can start several replicas at once, and
+// each one calls Up on startup; without this lock they'd race to insert the
+// same schema_migrations row and crash-loop on the primary-key violation.
+const lockKey = 0x626f6f6b73 // "books" packed into an int64
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Conn, letting the helpers
+// below run either against the pool or against a single locked connection.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// withAdvisoryLock runs fn against a single connection that holds a session-level
+// Postgres advisory lock for the duration, so only one pod at a time can migrate.
+func withAdvisoryLock(ctx context.Context, db *sql.DB, fn func(sqlExecer) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection for migration lock: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			// The unlock failed, so the backend session may still hold the
+			// lock. Evict this connection from the pool instead of handing
+			// a wedged session back to it, or every future Up/Down would
+			// block forever waiting on a lock nothing will ever release.
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return fn(conn)
+}
+
+// migration is one versioned schema change, loaded from a pair of
+// NNNN_name.up.sql / NNNN_name.down.sql files.
+type migration struct {
+	version int
+	name    string
+	upSQL   string
+	downSQL string
+}
+
+// load reads and pairs up every embedded migration file, sorted by version ascending.
+func load() ([]migration, error) {
+	entries, err := sqlFiles.ReadDir("sql")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations dir: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		version, name, direction, err := parseFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := sqlFiles.ReadFile("sql/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.upSQL = string(contents)
+		} else {
+			m.downSQL = string(contents)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	return migrations, nil
+}
+
+// parseFilename splits "0001_create_books.up.sql" into version 1, name "create_books"
+// and direction "up".
+func parseFilename(filename string) (version int, name string, direction string, err error) {
+	base := strings.TrimSuffix(filename, ".sql")
+	base, direction, ok := strings.Cut(base, ".")
+	if !ok || (direction != "up" && direction != "down") {
+		return 0, "", "", fmt.Errorf("migration filename %q must end in .up.sql or .down.sql", filename)
+	}
+
+	versionPart, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, "", "", fmt.Errorf("migration filename %q must be named NNNN_name.{up,down}.sql", filename)
+	}
+
+	version, err = strconv.Atoi(versionPart)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("migration filename %q has a non-numeric version: %w", filename, err)
+	}
+
+	return version, name, direction, nil
+}
+
+// ensureSchemaMigrationsTable creates the bookkeeping table that tracks which
+// migrations have already been applied.
+func ensureSchemaMigrationsTable(ctx context.Context, db sqlExecer) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, db sqlExecer) (map[int]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// Up applies every migration that hasn't run yet, in version order, holding the
+// migration advisory lock for the whole run so concurrent pods serialize instead
+// of racing. Each migration runs in its own span so schema changes are visible
+// in traces.
+func Up(ctx context.Context, db *sql.DB, tracer trace.Tracer) error {
+	ctx, span := tracer.Start(ctx, "migrations.up")
+	defer span.End()
+
+	return withAdvisoryLock(ctx, db, func(conn sqlExecer) error {
+		if err := ensureSchemaMigrationsTable(ctx, conn); err != nil {
+			return recordSpanError(span, "failed to ensure schema_migrations table", err)
+		}
+
+		migs, err := load()
+		if err != nil {
+			return recordSpanError(span, "failed to load migrations", err)
+		}
+
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return recordSpanError(span, "failed to read applied migrations", err)
+		}
+
+		for _, m := range migs {
+			if applied[m.version] {
+				continue
+			}
+			if err := apply(ctx, conn, tracer, m, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the most recently applied migration, holding the same
+// advisory lock as Up so it can't race a concurrent migration run.
+func Down(ctx context.Context, db *sql.DB, tracer trace.Tracer) error {
+	ctx, span := tracer.Start(ctx, "migrations.down")
+	defer span.End()
+
+	return withAdvisoryLock(ctx, db, func(conn sqlExecer) error {
+		if err := ensureSchemaMigrationsTable(ctx, conn); err != nil {
+			return recordSpanError(span, "failed to ensure schema_migrations table", err)
+		}
+
+		migs, err := load()
+		if err != nil {
+			return recordSpanError(span, "failed to load migrations", err)
+		}
+
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return recordSpanError(span, "failed to read applied migrations", err)
+		}
+
+		target := -1
+		for _, m := range migs {
+			if applied[m.version] && m.version > target {
+				target = m.version
+			}
+		}
+		if target == -1 {
+			return nil
+		}
+
+		for _, m := range migs {
+			if m.version == target {
+				return apply(ctx, conn, tracer, m, false)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Version returns the highest applied migration version, or 0 if none have run.
+func Version(ctx context.Context, db *sql.DB) (int, error) {
+	if err := ensureSchemaMigrationsTable(ctx, db); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// apply runs a single migration's up or down SQL in its own span and transaction,
+// recording the schema_migrations bookkeeping row alongside it.
+func apply(ctx context.Context, db sqlExecer, tracer trace.Tracer, m migration, up bool) error {
+	direction := "down"
+	query := m.downSQL
+	if up {
+		direction = "up"
+		query = m.upSQL
+	}
+
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("migrations.apply.%04d_%s.%s", m.version, m.name, direction))
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("migration.version", m.version),
+		attribute.String("migration.name", m.name),
+		attribute.String("migration.direction", direction),
+	)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return recordSpanError(span, "failed to begin migration transaction", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		tx.Rollback()
+		return recordSpanError(span, "failed to apply migration", err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", m.version)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return recordSpanError(span, "failed to record migration version", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return recordSpanError(span, "failed to commit migration", err)
+	}
+
+	return nil
+}
+
+func recordSpanError(span trace.Span, msg string, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, msg)
+	return fmt.Errorf("%s: %w", msg, err)
+}
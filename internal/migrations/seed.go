@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sampleBooks are the rows inserted when SEED_BOOKS=true, for demos and local testing.
+var sampleBooks = []struct {
+	title, author, summary string
+}{
+	{"The Pragmatic Programmer", "David Thomas & Andrew Hunt", "Classic tips for writing better software."},
+	{"Structure and Interpretation of Computer Programs", "Harold Abelson & Gerald Jay Sussman", "A foundational text on programming and computation."},
+	{"Designing Data-Intensive Applications", "Martin Kleppmann", "How to build reliable, scalable, and maintainable systems."},
+}
+
+// Seed inserts a few sample books if the table is empty, for demo and testing
+// environments. It holds the same advisory lock as Up/Down so two pods can't
+// both observe an empty table and each insert the sample rows.
+func Seed(ctx context.Context, db *sql.DB, tracer trace.Tracer) error {
+	ctx, span := tracer.Start(ctx, "migrations.seed")
+	defer span.End()
+
+	return withAdvisoryLock(ctx, db, func(conn sqlExecer) error {
+		var count int
+		if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM books").Scan(&count); err != nil {
+			return recordSpanError(span, "failed to count books", err)
+		}
+		if count > 0 {
+			span.SetAttributes(attribute.String("seed.status", "skipped_not_empty"))
+			return nil
+		}
+
+		for _, b := range sampleBooks {
+			if _, err := conn.ExecContext(ctx,
+				"INSERT INTO books (title, author, summary) VALUES ($1, $2, $3)",
+				b.title, b.author, b.summary,
+			); err != nil {
+				return recordSpanError(span, "failed to insert seed book", err)
+			}
+		}
+
+		span.SetAttributes(
+			attribute.String("seed.status", "inserted"),
+			attribute.Int("seed.books.count", len(sampleBooks)),
+		)
+		return nil
+	})
+}
@@ -4,18 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/XSAM/otelsql"
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
 	_ "github.com/lib/pq"
+	"github.com/tadamhicks/bread-test/internal/migrations"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
@@ -25,6 +40,13 @@ import (
 var (
 	db     *sql.DB
 	tracer trace.Tracer
+	meter  metric.Meter
+	logger *slog.Logger
+
+	requestsTotal    metric.Int64Counter
+	requestDuration  metric.Float64Histogram
+	dbQueryDuration  metric.Float64Histogram
+	requestsInFlight metric.Int64UpDownCounter
 )
 
 // Book represents a single book entity
@@ -35,23 +57,10 @@ type Book struct {
 	Summary string `json:"summary"`
 }
 
-// initTracer initializes OpenTelemetry tracing
-func initTracer() (*sdktrace.TracerProvider, error) {
-	// Create OTLP HTTP exporter
-	endpoint := getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4318")
-	log.Printf("OpenTelemetry endpoint: %s", endpoint)
-	exporter, err := otlptracehttp.New(
-		context.Background(),
-		otlptracehttp.WithEndpoint(endpoint),
-		otlptracehttp.WithInsecure(),
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create resource with service information
-	res, err := resource.New(
-		context.Background(),
+// newResource builds the Resource shared by the tracer and meter providers.
+func newResource(ctx context.Context) (*resource.Resource, error) {
+	return resource.New(
+		ctx,
 		resource.WithAttributes(
 			semconv.ServiceName(getEnvOrDefault("OTEL_SERVICE_NAME", "bookapi")),
 			semconv.ServiceVersion(getEnvOrDefault("OTEL_SERVICE_VERSION", "1.0.0")),
@@ -64,16 +73,119 @@ func initTracer() (*sdktrace.TracerProvider, error) {
 			semconv.K8SClusterName(getEnvOrDefault("CLUSTER_NAME", "automode-cluster")),
 		),
 	)
+}
+
+// newTraceExporter builds the span exporter selected by OTEL_TRACES_EXPORTER
+// (otlphttp, otlpgrpc, stdout or none), mirroring the OpenTelemetry demo services so the
+// binary can be pointed at different backends without a rebuild. A nil exporter with a
+// nil error means "none" - the tracer provider should record spans without exporting them.
+func newTraceExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	headers := parseOTLPHeaders(getEnvOrDefault("OTEL_EXPORTER_OTLP_HEADERS", ""))
+
+	switch exporter := getEnvOrDefault("OTEL_TRACES_EXPORTER", "otlphttp"); exporter {
+	case "otlpgrpc":
+		endpoint := getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4317")
+		logger.Info("OpenTelemetry traces exporter configured", "exporter", exporter, "endpoint", endpoint)
+		return otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(endpoint),
+			otlptracegrpc.WithInsecure(),
+			otlptracegrpc.WithHeaders(headers),
+		)
+	case "stdout":
+		logger.Info("OpenTelemetry traces exporter configured", "exporter", exporter)
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "none":
+		logger.Info("OpenTelemetry traces exporter configured", "exporter", exporter)
+		return nil, nil
+	case "otlphttp":
+		endpoint := getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4318")
+		logger.Info("OpenTelemetry traces exporter configured", "exporter", exporter, "endpoint", endpoint)
+		return otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpoint(endpoint),
+			otlptracehttp.WithInsecure(),
+			otlptracehttp.WithHeaders(headers),
+		)
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q", exporter)
+	}
+}
+
+// newSampler builds the sampler selected by OTEL_TRACES_SAMPLER, with OTEL_TRACES_SAMPLER_ARG
+// supplying the ratio for the traceidratio samplers.
+func newSampler() (sdktrace.Sampler, error) {
+	switch sampler := getEnvOrDefault("OTEL_TRACES_SAMPLER", "always_on"); sampler {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		ratio, err := samplerRatio()
+		if err != nil {
+			return nil, err
+		}
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case "parentbased_traceidratio":
+		ratio, err := samplerRatio()
+		if err != nil {
+			return nil, err
+		}
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_SAMPLER %q", sampler)
+	}
+}
+
+func samplerRatio() (float64, error) {
+	raw := getEnvOrDefault("OTEL_TRACES_SAMPLER_ARG", "1.0")
+	ratio, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %q: %w", raw, err)
+	}
+	return ratio, nil
+}
+
+// parseOTLPHeaders parses the standard OTEL_EXPORTER_OTLP_HEADERS format
+// ("key1=value1,key2=value2") used to authenticate against SaaS backends.
+func parseOTLPHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+// initTracer builds the tracer provider: an exporter selected by newTraceExporter,
+// a sampler selected by newSampler, and the shared resource, then installs both
+// as the global tracer provider and propagator.
+func initTracer(res *resource.Resource) (*sdktrace.TracerProvider, error) {
+	exporter, err := newTraceExporter(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	// Create tracer provider
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+	sampler, err := newSampler()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-	)
+		sdktrace.WithSampler(sampler),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	// Create tracer provider
+	tp := sdktrace.NewTracerProvider(opts...)
 
 	// Set global tracer provider
 	otel.SetTracerProvider(tp)
@@ -88,6 +200,113 @@ func initTracer() (*sdktrace.TracerProvider, error) {
 	return tp, nil
 }
 
+// initMeter initializes OpenTelemetry metrics, sharing the tracer's resource.
+func initMeter(res *resource.Resource) (*sdkmetric.MeterProvider, error) {
+	endpoint := getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4318")
+	exporter, err := otlpmetrichttp.New(
+		context.Background(),
+		otlpmetrichttp.WithEndpoint(endpoint),
+		otlpmetrichttp.WithInsecure(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetMeterProvider(mp)
+	meter = mp.Meter("bookapi")
+
+	if err := registerInstruments(); err != nil {
+		return nil, err
+	}
+
+	return mp, nil
+}
+
+// registerInstruments creates the counters, histograms and gauge used across handlers.
+func registerInstruments() error {
+	var err error
+
+	requestsTotal, err = meter.Int64Counter(
+		"bookapi.requests.total",
+		metric.WithDescription("Total number of HTTP requests by method and status"),
+	)
+	if err != nil {
+		return err
+	}
+
+	requestDuration, err = meter.Float64Histogram(
+		"bookapi.request.duration",
+		metric.WithDescription("HTTP request duration in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	dbQueryDuration, err = meter.Float64Histogram(
+		"bookapi.db.query.duration",
+		metric.WithDescription("Database query duration in seconds, by operation and table"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	requestsInFlight, err = meter.Int64UpDownCounter(
+		"bookapi.requests.inflight",
+		metric.WithDescription("Number of HTTP requests currently being handled"),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// recordRequestMetrics records the standard per-request counter and duration histogram.
+func recordRequestMetrics(ctx context.Context, method string, status int, start time.Time) {
+	attrs := metric.WithAttributes(
+		attribute.String("method", method),
+		attribute.Int("status", status),
+	)
+	requestsTotal.Add(ctx, 1, attrs)
+	requestDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+}
+
+// recordDBQueryDuration records the db.query.duration histogram for a single query.
+func recordDBQueryDuration(ctx context.Context, operation, table string, start time.Time) {
+	dbQueryDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("operation", operation),
+		attribute.String("table", table),
+	))
+}
+
+// logWithTrace returns a logger enriched with the trace_id/span_id of the span in ctx,
+// so log lines can be pivoted to the corresponding trace in groundcover/Loki.
+func logWithTrace(ctx context.Context) *slog.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return logger
+	}
+	return logger.With(
+		slog.String("trace_id", sc.TraceID().String()),
+		slog.String("span_id", sc.SpanID().String()),
+	)
+}
+
+// recordError records err on span, marks the span as failed, and logs it once
+// with trace correlation fields, replacing the old per-handler error.message attribute.
+func recordError(ctx context.Context, span trace.Span, msg string, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, msg)
+	logWithTrace(ctx).Error(msg, "error", err)
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -95,30 +314,72 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
-func booksHandler(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	span := trace.SpanFromContext(ctx)
+// statusWriter wraps http.ResponseWriter to capture the status code written,
+// so it can be reported to the requests.total and request.duration instruments.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
 
-	// Add request attributes to span
-	span.SetAttributes(
-		attribute.String("http.method", r.Method),
-		attribute.String("http.url", r.URL.String()),
-		attribute.String("http.user_agent", r.Header.Get("User-Agent")),
-	)
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
 
-	switch r.Method {
-	case http.MethodGet:
-		handleGetBooks(w, r)
-	case http.MethodPost:
-		handleCreateBook(w, r)
-	case http.MethodPut:
-		handleUpdateBook(w, r)
-	case http.MethodDelete:
-		handleDeleteBook(w, r)
-	default:
-		span.SetAttributes(attribute.String("error", "method_not_allowed"))
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// metricsMiddleware records the in-flight gauge and the requests.total/request.duration
+// instruments for every route, and annotates the otelhttp root span with request attributes.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		start := time.Now()
+
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.url", r.URL.String()),
+			attribute.String("http.user_agent", r.Header.Get("User-Agent")),
+		)
+
+		requestsInFlight.Add(ctx, 1)
+		defer requestsInFlight.Add(ctx, -1)
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		recordRequestMetrics(ctx, r.Method, sw.status, start)
+	})
+}
+
+// routeTag annotates the span and HTTP server metrics for h with the chi route pattern,
+// so span names carry e.g. "/books/{id}" instead of the low-cardinality "/books".
+func routeTag(pattern string, h http.HandlerFunc) http.HandlerFunc {
+	return otelhttp.WithRouteTag(pattern, h).ServeHTTP
+}
+
+// requestIDHeaderMiddleware echoes chi's per-request ID back to the client.
+func requestIDHeaderMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reqID := chimw.GetReqID(r.Context()); reqID != "" {
+			w.Header().Set("X-Request-Id", reqID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bookIDFromRequest reads the book ID from the chi path parameter, falling back to the
+// legacy "?id=" query parameter so existing clients keep working for one release.
+func bookIDFromRequest(r *http.Request) string {
+	if id := chi.URLParam(r, "id"); id != "" {
+		return id
 	}
+	return r.URL.Query().Get("id")
+}
+
+// writeJSONError writes a consistent {"error": "..."} JSON body for a failed request.
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
 
 func handleGetBooks(w http.ResponseWriter, r *http.Request) {
@@ -126,7 +387,7 @@ func handleGetBooks(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(ctx, "handleGetBooks")
 	defer span.End()
 
-	id := r.URL.Query().Get("id")
+	id := bookIDFromRequest(r)
 	var err error
 	var rows *sql.Rows
 
@@ -136,31 +397,17 @@ func handleGetBooks(w http.ResponseWriter, r *http.Request) {
 		attribute.String("operation", "get_books"),
 	)
 
+	queryStart := time.Now()
 	if id != "" {
-		ctx, querySpan := tracer.Start(ctx, "db.query.get_book_by_id")
-		querySpan.SetAttributes(
-			attribute.String("db.operation", "SELECT"),
-			attribute.String("db.table", "books"),
-			attribute.String("db.query.id", id),
-		)
 		rows, err = db.QueryContext(ctx, "SELECT id, title, author, summary FROM books WHERE id = $1", id)
-		querySpan.End()
 	} else {
-		ctx, querySpan := tracer.Start(ctx, "db.query.get_all_books")
-		querySpan.SetAttributes(
-			attribute.String("db.operation", "SELECT"),
-			attribute.String("db.table", "books"),
-		)
 		rows, err = db.QueryContext(ctx, "SELECT id, title, author, summary FROM books")
-		querySpan.End()
 	}
+	recordDBQueryDuration(ctx, "SELECT", "books", queryStart)
 
 	if err != nil {
-		span.SetAttributes(
-			attribute.String("error", "query_failed"),
-			attribute.String("error.message", err.Error()),
-		)
-		http.Error(w, "Failed to query books", http.StatusInternalServerError)
+		recordError(ctx, span, "failed to query books", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to query books")
 		return
 	}
 	defer rows.Close()
@@ -172,13 +419,9 @@ func handleGetBooks(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var b Book
 		if err := rows.Scan(&b.ID, &b.Title, &b.Author, &b.Summary); err != nil {
-			scanSpan.SetAttributes(
-				attribute.String("error", "scan_failed"),
-				attribute.String("error.message", err.Error()),
-			)
+			recordError(ctx, scanSpan, "failed to scan book", err)
 			scanSpan.End()
-			span.SetAttributes(attribute.String("error", "scan_failed"))
-			http.Error(w, "Failed to scan book", http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to scan book")
 			return
 		}
 		books = append(books, b)
@@ -189,11 +432,8 @@ func handleGetBooks(w http.ResponseWriter, r *http.Request) {
 	scanSpan.End()
 
 	if err := rows.Err(); err != nil {
-		span.SetAttributes(
-			attribute.String("error", "rows_error"),
-			attribute.String("error.message", err.Error()),
-		)
-		http.Error(w, "Rows error", http.StatusInternalServerError)
+		recordError(ctx, span, "rows error", err)
+		writeJSONError(w, http.StatusInternalServerError, "Rows error")
 		return
 	}
 
@@ -206,6 +446,40 @@ func handleGetBooks(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(books)
 }
 
+// handleGetBook serves GET /books/{id}, returning a single book or 404.
+func handleGetBook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "handleGetBook")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	span.SetAttributes(
+		attribute.String("book.id", id),
+		attribute.String("operation", "get_book"),
+	)
+
+	queryStart := time.Now()
+	row := db.QueryRowContext(ctx, "SELECT id, title, author, summary FROM books WHERE id = $1", id)
+	recordDBQueryDuration(ctx, "SELECT", "books", queryStart)
+
+	var b Book
+	if err := row.Scan(&b.ID, &b.Title, &b.Author, &b.Summary); err != nil {
+		if err == sql.ErrNoRows {
+			span.SetAttributes(attribute.String("error", "book_not_found"))
+			writeJSONError(w, http.StatusNotFound, "Book not found")
+			return
+		}
+		recordError(ctx, span, "failed to query book", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to query book")
+		return
+	}
+
+	span.SetAttributes(attribute.String("response.status", "success"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b)
+}
+
 func handleCreateBook(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ctx, span := tracer.Start(ctx, "handleCreateBook")
@@ -215,11 +489,8 @@ func handleCreateBook(w http.ResponseWriter, r *http.Request) {
 
 	var book Book
 	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		span.SetAttributes(
-			attribute.String("error", "invalid_request_body"),
-			attribute.String("error.message", err.Error()),
-		)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		recordError(ctx, span, "invalid request body", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -230,23 +501,15 @@ func handleCreateBook(w http.ResponseWriter, r *http.Request) {
 		attribute.Int("book.summary.length", len(book.Summary)),
 	)
 
-	ctx, dbSpan := tracer.Start(ctx, "db.insert.book")
-	dbSpan.SetAttributes(
-		attribute.String("db.operation", "INSERT"),
-		attribute.String("db.table", "books"),
-	)
-
+	queryStart := time.Now()
 	result, err := db.ExecContext(ctx,
 		"INSERT INTO books (title, author, summary) VALUES ($1, $2, $3) RETURNING id",
 		book.Title, book.Author, book.Summary)
-	dbSpan.End()
+	recordDBQueryDuration(ctx, "INSERT", "books", queryStart)
 
 	if err != nil {
-		span.SetAttributes(
-			attribute.String("error", "create_failed"),
-			attribute.String("error.message", err.Error()),
-		)
-		http.Error(w, "Failed to create book", http.StatusInternalServerError)
+		recordError(ctx, span, "failed to create book", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create book")
 		return
 	}
 
@@ -268,10 +531,10 @@ func handleUpdateBook(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(ctx, "handleUpdateBook")
 	defer span.End()
 
-	id := r.URL.Query().Get("id")
+	id := bookIDFromRequest(r)
 	if id == "" {
 		span.SetAttributes(attribute.String("error", "missing_book_id"))
-		http.Error(w, "Missing book ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Missing book ID")
 		return
 	}
 
@@ -282,11 +545,8 @@ func handleUpdateBook(w http.ResponseWriter, r *http.Request) {
 
 	var book Book
 	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		span.SetAttributes(
-			attribute.String("error", "invalid_request_body"),
-			attribute.String("error.message", err.Error()),
-		)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		recordError(ctx, span, "invalid request body", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -296,24 +556,15 @@ func handleUpdateBook(w http.ResponseWriter, r *http.Request) {
 		attribute.Int("book.summary.length", len(book.Summary)),
 	)
 
-	ctx, dbSpan := tracer.Start(ctx, "db.update.book")
-	dbSpan.SetAttributes(
-		attribute.String("db.operation", "UPDATE"),
-		attribute.String("db.table", "books"),
-		attribute.String("db.query.id", id),
-	)
-
+	queryStart := time.Now()
 	result, err := db.ExecContext(ctx,
 		"UPDATE books SET title = $1, author = $2, summary = $3 WHERE id = $4",
 		book.Title, book.Author, book.Summary, id)
-	dbSpan.End()
+	recordDBQueryDuration(ctx, "UPDATE", "books", queryStart)
 
 	if err != nil {
-		span.SetAttributes(
-			attribute.String("error", "update_failed"),
-			attribute.String("error.message", err.Error()),
-		)
-		http.Error(w, "Failed to update book", http.StatusInternalServerError)
+		recordError(ctx, span, "failed to update book", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update book")
 		return
 	}
 
@@ -322,7 +573,7 @@ func handleUpdateBook(w http.ResponseWriter, r *http.Request) {
 
 	if rowsAffected == 0 {
 		span.SetAttributes(attribute.String("error", "book_not_found"))
-		http.Error(w, "Book not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "Book not found")
 		return
 	}
 
@@ -335,10 +586,10 @@ func handleDeleteBook(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(ctx, "handleDeleteBook")
 	defer span.End()
 
-	id := r.URL.Query().Get("id")
+	id := bookIDFromRequest(r)
 	if id == "" {
 		span.SetAttributes(attribute.String("error", "missing_book_id"))
-		http.Error(w, "Missing book ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Missing book ID")
 		return
 	}
 
@@ -347,22 +598,13 @@ func handleDeleteBook(w http.ResponseWriter, r *http.Request) {
 		attribute.String("book.id", id),
 	)
 
-	ctx, dbSpan := tracer.Start(ctx, "db.delete.book")
-	dbSpan.SetAttributes(
-		attribute.String("db.operation", "DELETE"),
-		attribute.String("db.table", "books"),
-		attribute.String("db.query.id", id),
-	)
-
+	queryStart := time.Now()
 	result, err := db.ExecContext(ctx, "DELETE FROM books WHERE id = $1", id)
-	dbSpan.End()
+	recordDBQueryDuration(ctx, "DELETE", "books", queryStart)
 
 	if err != nil {
-		span.SetAttributes(
-			attribute.String("error", "delete_failed"),
-			attribute.String("error.message", err.Error()),
-		)
-		http.Error(w, "Failed to delete book", http.StatusInternalServerError)
+		recordError(ctx, span, "failed to delete book", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to delete book")
 		return
 	}
 
@@ -371,7 +613,7 @@ func handleDeleteBook(w http.ResponseWriter, r *http.Request) {
 
 	if rowsAffected == 0 {
 		span.SetAttributes(attribute.String("error", "book_not_found"))
-		http.Error(w, "Book not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, "Book not found")
 		return
 	}
 
@@ -381,7 +623,7 @@ func handleDeleteBook(w http.ResponseWriter, r *http.Request) {
 
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	ctx, span := tracer.Start(ctx, "healthCheck")
+	_, span := tracer.Start(ctx, "healthCheck")
 	defer span.End()
 
 	span.SetAttributes(
@@ -393,37 +635,129 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
 
+// readyzHandler reports whether the service is ready to take traffic by pinging the DB.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, span := tracer.Start(ctx, "readyCheck")
+	defer span.End()
+
+	if err := db.PingContext(ctx); err != nil {
+		recordError(ctx, span, "database not ready", err)
+		writeJSONError(w, http.StatusServiceUnavailable, "Database not ready")
+		return
+	}
+
+	span.SetAttributes(attribute.String("response.status", "ready"))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
 func main() {
-	// Initialize OpenTelemetry
-	tp, err := initTracer()
+	migrateCmd := flag.String("migrate", "", "run a migration command against the database and exit: up, down, or version")
+	flag.Parse()
+
+	logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// Initialize OpenTelemetry tracing and metrics, sharing one resource between them
+	res, err := newResource(context.Background())
 	if err != nil {
-		log.Fatalf("Failed to initialize tracer: %v", err)
+		logger.Error("failed to create OpenTelemetry resource", "error", err)
+		os.Exit(1)
+	}
+
+	tp, err := initTracer(res)
+	if err != nil {
+		logger.Error("failed to initialize tracer", "error", err)
+		os.Exit(1)
 	}
 	defer func() {
 		if err := tp.Shutdown(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer provider: %v", err)
+			logger.Error("error shutting down tracer provider", "error", err)
 		}
 	}()
 
-	// Connect to Postgres
-	db, err = sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	// Connect to Postgres through otelsql so every query/exec gets a span
+	// (db.system, db.statement, errors) without each handler opening its own.
+	db, err = otelsql.Open("postgres", os.Getenv("DATABASE_URL"),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnectorConnect: true,
+		}),
+	)
 	if err != nil {
-		log.Fatalf("Failed to connect to DB: %v", err)
+		logger.Error("failed to connect to DB", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
 	// Test database connection
 	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+		logger.Error("failed to ping database", "error", err)
+		os.Exit(1)
+	}
+
+	if *migrateCmd != "" {
+		if err := runMigrateCommand(context.Background(), *migrateCmd); err != nil {
+			logger.Error("migrate command failed", "command", *migrateCmd, "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Self-bootstrap the schema on every startup so a fresh deployment doesn't
+	// crash on its first request.
+	if err := migrations.Up(context.Background(), db, tracer); err != nil {
+		logger.Error("failed to run migrations", "error", err)
+		os.Exit(1)
+	}
+
+	if getEnvOrDefault("SEED_BOOKS", "false") == "true" {
+		if err := migrations.Seed(context.Background(), db, tracer); err != nil {
+			logger.Error("failed to seed books", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	mp, err := initMeter(res)
+	if err != nil {
+		logger.Error("failed to initialize meter", "error", err)
+		os.Exit(1)
 	}
+	defer func() {
+		if err := mp.Shutdown(context.Background()); err != nil {
+			logger.Error("error shutting down meter provider", "error", err)
+		}
+	}()
+
+	// Create router with RESTful /books routes plus a /books?id= compatibility
+	// shim for existing clients, kept for one release
+	r := chi.NewRouter()
+	r.Use(chimw.RequestID)
+	r.Use(requestIDHeaderMiddleware)
+	r.Use(metricsMiddleware)
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusNotFound, "Not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	})
 
-	// Create HTTP mux
-	mux := http.NewServeMux()
-	mux.HandleFunc("/books", booksHandler)
-	mux.HandleFunc("/healthz", healthCheckHandler)
+	r.Get("/healthz", routeTag("/healthz", healthCheckHandler))
+	r.Get("/readyz", routeTag("/readyz", readyzHandler))
 
-	// Wrap mux with OpenTelemetry HTTP middleware
-	handler := otelhttp.NewHandler(mux, "bookapi")
+	r.Get("/books", routeTag("/books", handleGetBooks))
+	r.Post("/books", routeTag("/books", handleCreateBook))
+	r.Put("/books", routeTag("/books", handleUpdateBook))
+	r.Delete("/books", routeTag("/books", handleDeleteBook))
+
+	r.Get("/books/{id}", routeTag("/books/{id}", handleGetBook))
+	r.Put("/books/{id}", routeTag("/books/{id}", handleUpdateBook))
+	r.Delete("/books/{id}", routeTag("/books/{id}", handleDeleteBook))
+
+	// Wrap the router with OpenTelemetry HTTP middleware, reporting through the same meter
+	// provider so standard HTTP server metrics show up alongside the custom bookapi.* instruments
+	handler := otelhttp.NewHandler(r, "bookapi", otelhttp.WithMeterProvider(mp))
 
 	// Create server
 	server := &http.Server{
@@ -433,26 +767,55 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		log.Println("Server is running on :9292")
+		logger.Info("server is running", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+			logger.Error("server failed to start", "error", err)
+			os.Exit(1)
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown
+	// Wait for SIGINT or SIGTERM (the latter is what Kubernetes sends on pod eviction)
+	// to gracefully shutdown
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logger.Info("shutting down server")
 
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		logger.Error("server forced to shutdown", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Server exited")
+	logger.Info("server exited")
+}
+
+// runMigrateCommand handles the -migrate flag: up, down, or version. It talks
+// directly to the package-level db, which main has already opened and pinged.
+func runMigrateCommand(ctx context.Context, cmd string) error {
+	switch cmd {
+	case "up":
+		if err := migrations.Up(ctx, db, tracer); err != nil {
+			return err
+		}
+		logger.Info("migrations applied")
+	case "down":
+		if err := migrations.Down(ctx, db, tracer); err != nil {
+			return err
+		}
+		logger.Info("migration rolled back")
+	case "version":
+		version, err := migrations.Version(ctx, db)
+		if err != nil {
+			return err
+		}
+		logger.Info("schema version", "version", version)
+	default:
+		return fmt.Errorf("unknown -migrate command %q (expected up, down, or version)", cmd)
+	}
+	return nil
 }